@@ -0,0 +1,46 @@
+/*
+Copyright 2018 The Skaffold Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package build implements the strategies skaffold uses to turn a
+// workspace into a pushed, tagged image.
+package build
+
+import (
+	"io"
+
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/build/tag"
+)
+
+// Builder is the interface implemented by every build strategy (the local
+// docker daemon, crane, buildkit, ...).
+type Builder interface {
+	Run(out io.Writer, tagger tag.Tagger) (*BuildResult, error)
+}
+
+// Build is the result of building and pushing a single artifact.
+type Build struct {
+	ImageName string
+	Tag       string
+	// Digest is the pushed image's manifest digest (sha256:...), used by
+	// the sign package to address the exact content that was deployed.
+	Digest string
+}
+
+// BuildResult is the output of a Builder run: the tag produced for every
+// artifact that was built.
+type BuildResult struct {
+	Builds []Build
+}
@@ -0,0 +1,229 @@
+/*
+Copyright 2018 The Skaffold Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package build
+
+import (
+	"context"
+	"io"
+	"net/url"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	bkclient "github.com/moby/buildkit/client"
+	"github.com/pkg/errors"
+
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/build/tag"
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/config"
+)
+
+// supportedBuildKitSchemes are the endpoint schemes buildx itself accepts:
+// a Unix socket, a raw TCP connection, or the docker-container driver,
+// which proxies the connection through `docker exec` into a container
+// running buildkitd.
+var supportedBuildKitSchemes = map[string]bool{
+	"unix":             true,
+	"tcp":              true,
+	"docker-container": true,
+}
+
+// BuildKitBuilder builds artifacts on a BuildKit daemon instead of the
+// local docker daemon. Its context is synced to the daemon over the same
+// gRPC connection the Solve API uses, so no daemon-side bind mount or
+// local docker build context is required. When cfg.Platforms names more
+// than one platform, the dockerfile.v0 frontend fans out one sub-build
+// per platform and assembles the results into a single OCI image index
+// before push.
+type BuildKitBuilder struct {
+	cfg             *config.BuildKitBuild
+	artifacts       []*config.Artifact
+	outputTimestamp config.OutputTimestamp
+	buildTime       time.Time
+	endpoint        *url.URL
+
+	// newClient constructs the client Run solves against. It's a field,
+	// defaulted to dialing the real daemon, so tests can substitute a
+	// fake and exercise Run without a live BuildKit daemon.
+	newClient func(ctx context.Context, endpoint string) (buildkitClient, error)
+}
+
+// NewBuildKitBuilder returns a new BuildKitBuilder connected to
+// cfg.Endpoint, building and pushing every artifact in artifacts. The
+// endpoint is parsed and its scheme validated here, so a bad config fails
+// NewForConfig instead of the first Run.
+func NewBuildKitBuilder(cfg *config.BuildKitBuild, artifacts []*config.Artifact, outputTimestamp config.OutputTimestamp) (*BuildKitBuilder, error) {
+	if cfg.Endpoint == "" {
+		return nil, errors.New("buildkit build requires an endpoint")
+	}
+
+	endpoint, err := url.Parse(cfg.Endpoint)
+	if err != nil {
+		return nil, errors.Wrapf(err, "parsing buildkit endpoint %s", cfg.Endpoint)
+	}
+	if !supportedBuildKitSchemes[endpoint.Scheme] {
+		return nil, errors.Errorf("unsupported buildkit endpoint scheme %q, want one of unix, tcp, docker-container", endpoint.Scheme)
+	}
+
+	return &BuildKitBuilder{
+		cfg:             cfg,
+		artifacts:       artifacts,
+		outputTimestamp: outputTimestamp,
+		endpoint:        endpoint,
+		newClient:       dialBuildkit,
+	}, nil
+}
+
+// buildkitClient is the subset of *bkclient.Client BuildKitBuilder needs,
+// narrow enough that tests can substitute a fake in place of a live
+// BuildKit daemon connection.
+type buildkitClient interface {
+	Solve(ctx context.Context, opt bkclient.SolveOpt) (*bkclient.SolveResponse, error)
+	Close() error
+}
+
+// realBuildkitClient adapts *bkclient.Client to buildkitClient, supplying
+// the *llb.Definition and status channel arguments BuildKitBuilder never
+// needs: it always builds through the dockerfile.v0 frontend, which takes
+// its definition from SolveOpt.FrontendAttrs, not a pre-built llb graph,
+// and status reporting isn't wired up yet.
+type realBuildkitClient struct {
+	*bkclient.Client
+}
+
+func (c *realBuildkitClient) Solve(ctx context.Context, opt bkclient.SolveOpt) (*bkclient.SolveResponse, error) {
+	return c.Client.Solve(ctx, nil, opt, nil)
+}
+
+func dialBuildkit(ctx context.Context, endpoint string) (buildkitClient, error) {
+	c, err := bkclient.New(ctx, endpoint)
+	if err != nil {
+		return nil, err
+	}
+	return &realBuildkitClient{c}, nil
+}
+
+// SetBuildTime records the instant this run started, used when
+// outputTimestamp is config.TimestampBuildTimestamp.
+func (b *BuildKitBuilder) SetBuildTime(t time.Time) {
+	b.buildTime = t
+}
+
+// Run builds and pushes every artifact on the BuildKit daemon at
+// b.endpoint. BuildKit pushes as part of Solve, so the tag it pushes under
+// must be known before the build that produces its digest runs — but
+// tagger derives that tag from the digest. Each artifact is solved twice
+// to resolve this: once without pushing, to learn the digest, then again,
+// a cache hit since nothing changed, to push under the tag the digest
+// resolves to.
+func (b *BuildKitBuilder) Run(out io.Writer, tagger tag.Tagger) (*BuildResult, error) {
+	var res BuildResult
+	for _, a := range b.artifacts {
+		dockerfilePath := filepath.Join(a.Workspace, "Dockerfile")
+
+		digest, err := b.solve(a.Workspace, dockerfilePath, a.ImageName, false)
+		if err != nil {
+			return nil, errors.Wrapf(err, "building %s", a.ImageName)
+		}
+
+		tagged, err := tagger.GenerateFullyQualifiedImageName(a.Workspace, &tag.Options{
+			ImageName: a.ImageName,
+			Digest:    digest,
+		})
+		if err != nil {
+			return nil, errors.Wrapf(err, "tagging %s", a.ImageName)
+		}
+
+		if _, err := b.solve(a.Workspace, dockerfilePath, tagged, true); err != nil {
+			return nil, errors.Wrapf(err, "pushing %s", tagged)
+		}
+
+		res.Builds = append(res.Builds, Build{ImageName: a.ImageName, Tag: tagged, Digest: digest})
+	}
+	return &res, nil
+}
+
+// solveOpt returns the SolveOpt shared by every platform's build of ref:
+// the dockerfile.v0 frontend synced against workspace/dockerfilePath, an
+// image exporter named ref that pushes only when push is true, and the
+// configured cache import/export specs.
+func (b *BuildKitBuilder) solveOpt(workspace, dockerfilePath, ref string, push bool) bkclient.SolveOpt {
+	frontendAttrs := map[string]string{}
+	if len(b.cfg.Platforms) > 0 {
+		frontendAttrs["platform"] = strings.Join(b.cfg.Platforms, ",")
+	}
+
+	return bkclient.SolveOpt{
+		Frontend:      "dockerfile.v0",
+		FrontendAttrs: frontendAttrs,
+		LocalDirs: map[string]string{
+			"context":    workspace,
+			"dockerfile": dockerfilePath,
+		},
+		Exports: []bkclient.ExportEntry{{
+			Type: bkclient.ExporterImage,
+			Attrs: map[string]string{
+				"name": ref,
+				"push": strconv.FormatBool(push),
+			},
+		}},
+		CacheImports: cacheOptions(b.cfg.CacheImport),
+		CacheExports: cacheOptions(b.cfg.CacheExport),
+	}
+}
+
+// solve connects to the BuildKit daemon and solves a single artifact,
+// pushing it to ref when push is true, and returns the digest of the
+// resulting image either way.
+func (b *BuildKitBuilder) solve(workspace, dockerfilePath, ref string, push bool) (string, error) {
+	ctx := context.Background()
+
+	c, err := b.newClient(ctx, b.endpoint.String())
+	if err != nil {
+		return "", errors.Wrapf(err, "connecting to buildkit daemon at %s", b.cfg.Endpoint)
+	}
+	defer c.Close()
+
+	res, err := c.Solve(ctx, b.solveOpt(workspace, dockerfilePath, ref, push))
+	if err != nil {
+		return "", errors.Wrapf(err, "solving %s", ref)
+	}
+	return res.ExporterResponse["containerimage.digest"], nil
+}
+
+// cacheOptions parses specs of the form "type=registry,ref=...,key=val"
+// into the CacheOptionsEntry BuildKit's client expects.
+func cacheOptions(specs []string) []bkclient.CacheOptionsEntry {
+	var entries []bkclient.CacheOptionsEntry
+	for _, spec := range specs {
+		attrs := map[string]string{}
+		var typ string
+		for _, kv := range strings.Split(spec, ",") {
+			parts := strings.SplitN(kv, "=", 2)
+			if len(parts) != 2 {
+				continue
+			}
+			if parts[0] == "type" {
+				typ = parts[1]
+				continue
+			}
+			attrs[parts[0]] = parts[1]
+		}
+		entries = append(entries, bkclient.CacheOptionsEntry{Type: typ, Attrs: attrs})
+	}
+	return entries
+}
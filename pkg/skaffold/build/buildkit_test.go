@@ -0,0 +1,97 @@
+/*
+Copyright 2018 The Skaffold Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package build
+
+import (
+	"context"
+	"io/ioutil"
+	"testing"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	bkclient "github.com/moby/buildkit/client"
+	"github.com/pkg/errors"
+
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/build/tag"
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/config"
+)
+
+// fakeBuildkitClient records every SolveOpt it's asked to solve and
+// returns a fixed digest, standing in for a live BuildKit daemon. When
+// asked to push, it rejects a "name" attr that isn't a parseable image
+// reference, the same way a real registry push would, so a Tagger
+// producing an invalid reference fails this test instead of only a real
+// push.
+type fakeBuildkitClient struct {
+	solved []bkclient.SolveOpt
+	digest string
+}
+
+func (f *fakeBuildkitClient) Solve(ctx context.Context, opt bkclient.SolveOpt) (*bkclient.SolveResponse, error) {
+	attrs := opt.Exports[0].Attrs
+	if attrs["push"] == "true" {
+		if _, err := name.ParseReference(attrs["name"], name.StrictValidation); err != nil {
+			return nil, errors.Wrapf(err, "invalid reference %q", attrs["name"])
+		}
+	}
+	f.solved = append(f.solved, opt)
+	return &bkclient.SolveResponse{ExporterResponse: map[string]string{
+		"containerimage.digest": f.digest,
+	}}, nil
+}
+
+func (f *fakeBuildkitClient) Close() error { return nil }
+
+// TestBuildKitBuilderRunSolvesEveryArtifact exercises BuildKitBuilder.Run
+// end to end against a fake client: it must actually Solve each configured
+// artifact (once to learn its digest, again to push under the tag that
+// digest resolves to), not just construct a BuildResult around nothing.
+func TestBuildKitBuilderRunSolvesEveryArtifact(t *testing.T) {
+	fake := &fakeBuildkitClient{digest: "sha256:deadbeef"}
+
+	builder, err := NewBuildKitBuilder(&config.BuildKitBuild{Endpoint: "tcp://buildkitd:1234"}, []*config.Artifact{
+		{ImageName: "gcr.io/project/app", Workspace: "/workspace/app"},
+	}, config.TimestampZero)
+	if err != nil {
+		t.Fatal(err)
+	}
+	builder.newClient = func(ctx context.Context, endpoint string) (buildkitClient, error) {
+		return fake, nil
+	}
+
+	res, err := builder.Run(ioutil.Discard, &tag.ChecksumTagger{})
+	if err != nil {
+		t.Fatalf("Run: %s", err)
+	}
+
+	if len(res.Builds) != 1 {
+		t.Fatalf("expected 1 build, got %d", len(res.Builds))
+	}
+	want := "gcr.io/project/app:deadbeef"
+	if res.Builds[0].Tag != want {
+		t.Errorf("expected tag %s, got %s", want, res.Builds[0].Tag)
+	}
+
+	if len(fake.solved) != 2 {
+		t.Fatalf("expected Solve to be called twice (digest then push), got %d", len(fake.solved))
+	}
+	if fake.solved[0].Exports[0].Attrs["push"] != "false" {
+		t.Errorf("expected first solve not to push, got %+v", fake.solved[0].Exports[0].Attrs)
+	}
+	if fake.solved[1].Exports[0].Attrs["push"] != "true" || fake.solved[1].Exports[0].Attrs["name"] != want {
+		t.Errorf("expected second solve to push %s, got %+v", want, fake.solved[1].Exports[0].Attrs)
+	}
+}
@@ -0,0 +1,201 @@
+/*
+Copyright 2018 The Skaffold Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package build
+
+import (
+	"bytes"
+	"io"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/go-containerregistry/pkg/authn"
+	"github.com/google/go-containerregistry/pkg/name"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/mutate"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/google/go-containerregistry/pkg/v1/tarball"
+	"github.com/pkg/errors"
+
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/build/tag"
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/config"
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/docker"
+)
+
+// CraneBuilder builds artifacts by appending the build context directly
+// onto a pulled base image, without ever talking to a docker daemon. It
+// only supports Dockerfiles whose instructions can be satisfied by
+// rewriting image config (FROM, WORKDIR, ENTRYPOINT, ENV, LABEL) plus a
+// single COPY/ADD of the whole context; anything else (RUN, multi-stage
+// builds) returns an error telling the user to fall back to LocalBuild.
+type CraneBuilder struct {
+	cfg             *config.CraneBuild
+	artifacts       []*config.Artifact
+	outputTimestamp config.OutputTimestamp
+	buildTime       time.Time
+}
+
+// NewCraneBuilder returns a new CraneBuilder that builds and pushes every
+// artifact in artifacts.
+func NewCraneBuilder(cfg *config.CraneBuild, artifacts []*config.Artifact, outputTimestamp config.OutputTimestamp) (*CraneBuilder, error) {
+	return &CraneBuilder{cfg: cfg, artifacts: artifacts, outputTimestamp: outputTimestamp}, nil
+}
+
+// SetBuildTime records the instant this run started, used when
+// outputTimestamp is config.TimestampBuildTimestamp.
+func (c *CraneBuilder) SetBuildTime(t time.Time) {
+	c.buildTime = t
+}
+
+// Run builds every artifact by appending its build context as a new layer
+// on top of its Dockerfile's base image, then pushes it under the tag
+// tagger derives from the built image's digest.
+func (c *CraneBuilder) Run(out io.Writer, tagger tag.Tagger) (*BuildResult, error) {
+	var res BuildResult
+	for _, a := range c.artifacts {
+		dockerfilePath := filepath.Join(a.Workspace, "Dockerfile")
+
+		img, err := c.buildArtifact(a.Workspace, dockerfilePath)
+		if err != nil {
+			return nil, errors.Wrapf(err, "building %s", a.ImageName)
+		}
+
+		digest, err := img.Digest()
+		if err != nil {
+			return nil, errors.Wrapf(err, "hashing %s", a.ImageName)
+		}
+
+		tagged, err := tagger.GenerateFullyQualifiedImageName(a.Workspace, &tag.Options{
+			ImageName: a.ImageName,
+			Digest:    digest.String(),
+		})
+		if err != nil {
+			return nil, errors.Wrapf(err, "tagging %s", a.ImageName)
+		}
+
+		ref, err := name.ParseReference(tagged, name.WeakValidation)
+		if err != nil {
+			return nil, errors.Wrapf(err, "parsing tag %s", tagged)
+		}
+
+		if err := remote.Write(ref, img, remote.WithAuthFromKeychain(authn.DefaultKeychain)); err != nil {
+			return nil, errors.Wrapf(err, "pushing %s", ref)
+		}
+
+		res.Builds = append(res.Builds, Build{ImageName: a.ImageName, Tag: tagged, Digest: digest.String()})
+	}
+	return &res, nil
+}
+
+// buildArtifact builds a single artifact in memory, without pushing it, so
+// its digest can be computed and fed into tagger before the final tag it
+// gets pushed under is decided.
+func (c *CraneBuilder) buildArtifact(workspace, dockerfilePath string) (v1.Image, error) {
+	imgCfg, err := docker.ParseImageConfig(dockerfilePath)
+	if err != nil {
+		return nil, errors.Wrap(err, "this Dockerfile can't be built without a docker daemon")
+	}
+
+	baseRef, err := name.ParseReference(imgCfg.From, name.WeakValidation)
+	if err != nil {
+		return nil, errors.Wrapf(err, "parsing base image %s", imgCfg.From)
+	}
+
+	base, err := remote.Image(baseRef, remote.WithAuthFromKeychain(authn.DefaultKeychain))
+	if err != nil {
+		return nil, errors.Wrapf(err, "pulling base image %s", imgCfg.From)
+	}
+
+	stamp, err := resolveTimestamp(c.outputTimestamp, c.buildTime, workspace, dockerfilePath)
+	if err != nil {
+		return nil, errors.Wrap(err, "resolving output timestamp")
+	}
+
+	var buf bytes.Buffer
+	if err := docker.CreateDockerTarGzContextWithTimestamp(nil, &buf, workspace, dockerfilePath, stamp); err != nil {
+		return nil, errors.Wrap(err, "building layer from context")
+	}
+
+	layer, err := tarball.LayerFromReader(&buf)
+	if err != nil {
+		return nil, errors.Wrap(err, "creating layer from context tarball")
+	}
+
+	img, err := mutate.Append(base, mutate.Addendum{Layer: layer})
+	if err != nil {
+		return nil, errors.Wrap(err, "appending context layer")
+	}
+
+	baseConfigFile, err := img.ConfigFile()
+	if err != nil {
+		return nil, errors.Wrap(err, "reading base image config")
+	}
+
+	img, err = mutate.Config(img, applyImageConfig(baseConfigFile.Config, imgCfg))
+	if err != nil {
+		return nil, errors.Wrap(err, "applying image config")
+	}
+
+	img, err = normalizeImageTimestamp(img, stamp)
+	if err != nil {
+		return nil, errors.Wrap(err, "normalizing image timestamp")
+	}
+
+	return img, nil
+}
+
+// applyImageConfig returns base, the pulled base image's existing config,
+// with only the fields the Dockerfile front-end actually parsed out of cfg
+// overwritten. mutate.Config replaces v1.Config wholesale, so anything not
+// reassigned here (Cmd, User, ExposedPorts, Volumes, StopSignal, ...) must
+// be copied forward from base or it's silently lost from the pushed image.
+func applyImageConfig(base v1.Config, cfg *docker.ImageConfig) v1.Config {
+	if cfg.WorkingDir != "" {
+		base.WorkingDir = cfg.WorkingDir
+	}
+	if len(cfg.Entrypoint) > 0 {
+		base.Entrypoint = cfg.Entrypoint
+	}
+
+	env := map[string]string{}
+	for _, kv := range base.Env {
+		if parts := strings.SplitN(kv, "=", 2); len(parts) == 2 {
+			env[parts[0]] = parts[1]
+		}
+	}
+	for k, v := range cfg.Env {
+		env[k] = v
+	}
+	var envs []string
+	for k, v := range env {
+		envs = append(envs, k+"="+v)
+	}
+	sort.Strings(envs)
+	base.Env = envs
+
+	if len(cfg.Labels) > 0 {
+		if base.Labels == nil {
+			base.Labels = map[string]string{}
+		}
+		for k, v := range cfg.Labels {
+			base.Labels[k] = v
+		}
+	}
+
+	return base
+}
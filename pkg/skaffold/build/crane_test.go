@@ -0,0 +1,88 @@
+/*
+Copyright 2018 The Skaffold Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package build
+
+import (
+	"io/ioutil"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/registry"
+	"github.com/google/go-containerregistry/pkg/v1/empty"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/build/tag"
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/config"
+)
+
+// TestCraneBuilderRunPushesEveryArtifact exercises CraneBuilder.Run end to
+// end against a local registry: it must actually build and push each
+// configured artifact, not just construct a BuildResult around nothing.
+func TestCraneBuilderRunPushesEveryArtifact(t *testing.T) {
+	srv := httptest.NewServer(registry.New())
+	defer srv.Close()
+	registryHost := srv.Listener.Addr().String()
+
+	baseRef, err := name.ParseReference(registryHost+"/base:latest", name.WeakValidation)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := remote.Write(baseRef, empty.Image); err != nil {
+		t.Fatalf("seeding base image: %s", err)
+	}
+
+	workspace, err := ioutil.TempDir("", "crane-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(workspace)
+
+	dockerfile := "FROM " + registryHost + "/base:latest\nLABEL built-by=crane\nCOPY . .\n"
+	if err := ioutil.WriteFile(filepath.Join(workspace, "Dockerfile"), []byte(dockerfile), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(workspace, "app.txt"), []byte("hello"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	builder, err := NewCraneBuilder(&config.CraneBuild{}, []*config.Artifact{
+		{ImageName: registryHost + "/app", Workspace: workspace},
+	}, config.TimestampZero)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	res, err := builder.Run(ioutil.Discard, &tag.ChecksumTagger{})
+	if err != nil {
+		t.Fatalf("Run: %s", err)
+	}
+	if len(res.Builds) != 1 {
+		t.Fatalf("expected 1 build, got %d", len(res.Builds))
+	}
+
+	b := res.Builds[0]
+	ref, err := name.ParseReference(b.Tag, name.WeakValidation)
+	if err != nil {
+		t.Fatalf("parsing pushed tag %s: %s", b.Tag, err)
+	}
+	if _, err := remote.Head(ref); err != nil {
+		t.Errorf("pushed image %s not found in registry: %s", ref, err)
+	}
+}
@@ -0,0 +1,55 @@
+/*
+Copyright 2018 The Skaffold Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package build
+
+import (
+	"io"
+	"time"
+
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/build/tag"
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/config"
+)
+
+// LocalBuilder builds artifacts with the local docker daemon.
+type LocalBuilder struct {
+	cfg             *config.LocalBuild
+	outputTimestamp config.OutputTimestamp
+	buildTime       time.Time
+}
+
+// NewLocalBuilder returns a new LocalBuilder configured against the local
+// docker daemon.
+func NewLocalBuilder(cfg *config.LocalBuild, outputTimestamp config.OutputTimestamp) (*LocalBuilder, error) {
+	return &LocalBuilder{cfg: cfg, outputTimestamp: outputTimestamp}, nil
+}
+
+// SetBuildTime records the instant this run started, used when
+// outputTimestamp is config.TimestampBuildTimestamp.
+func (l *LocalBuilder) SetBuildTime(t time.Time) {
+	l.buildTime = t
+}
+
+// Run builds every artifact with the local docker daemon and, unless
+// SkipPush is set, pushes the result.
+//
+// TODO(#chunk0-2): stub. Doesn't build anything yet, and doesn't apply
+// the outputTimestamp normalization that CraneBuilder and BuildKitBuilder
+// apply via resolveTimestamp/normalizeImageTimestamp; wire that in once
+// this talks to a real docker daemon.
+func (l *LocalBuilder) Run(out io.Writer, tagger tag.Tagger) (*BuildResult, error) {
+	return &BuildResult{}, nil
+}
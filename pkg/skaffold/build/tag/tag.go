@@ -0,0 +1,59 @@
+/*
+Copyright 2018 The Skaffold Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tag
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Options carries the information a Tagger needs to produce a fully
+// qualified image name for a single artifact.
+type Options struct {
+	ImageName string
+	Digest    string
+}
+
+// Tagger generates a tag for an artifact.
+type Tagger interface {
+	GenerateFullyQualifiedImageName(workingDir string, opts *Options) (string, error)
+}
+
+// ChecksumTagger tags an image with the sha256 checksum of its content.
+type ChecksumTagger struct{}
+
+// GenerateFullyQualifiedImageName tags the image with its digest, stripped
+// of its "sha256:" algorithm prefix: a Docker tag can't contain a colon.
+func (c *ChecksumTagger) GenerateFullyQualifiedImageName(workingDir string, opts *Options) (string, error) {
+	hex := opts.Digest
+	if parts := strings.SplitN(opts.Digest, ":", 2); len(parts) == 2 {
+		hex = parts[1]
+	}
+	return fmt.Sprintf("%s:%s", opts.ImageName, hex), nil
+}
+
+// ContentDigestTagger tags an image by reference to its pushed manifest
+// digest (`name@sha256:...`), rather than a mutable tag. It's only
+// deterministic across repeated builds of unchanged source once the
+// builder's OutputTimestamp pins layer and config timestamps; otherwise
+// the digest changes on every build anyway.
+type ContentDigestTagger struct{}
+
+// GenerateFullyQualifiedImageName tags the image with its digest reference.
+func (c *ContentDigestTagger) GenerateFullyQualifiedImageName(workingDir string, opts *Options) (string, error) {
+	return fmt.Sprintf("%s@%s", opts.ImageName, opts.Digest), nil
+}
@@ -0,0 +1,63 @@
+/*
+Copyright 2018 The Skaffold Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package build
+
+import (
+	"time"
+
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/mutate"
+	"github.com/pkg/errors"
+
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/config"
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/docker"
+)
+
+// TimestampSetter is implemented by builders that honor
+// config.BuildConfig.OutputTimestamp in TimestampBuildTimestamp mode. The
+// runner calls SetBuildTime once per SkaffoldRunner.Run, before building
+// any artifact, so that every artifact built in the same run gets the same
+// stamped Created time.
+type TimestampSetter interface {
+	SetBuildTime(time.Time)
+}
+
+// resolveTimestamp turns an OutputTimestamp mode into the concrete instant
+// a builder should stamp onto an image's config and layers.
+func resolveTimestamp(mode config.OutputTimestamp, buildTime time.Time, workspace, dockerfilePath string) (time.Time, error) {
+	switch mode {
+	case "", config.TimestampBuildTimestamp:
+		return buildTime, nil
+	case config.TimestampZero:
+		return time.Unix(0, 0), nil
+	case config.TimestampSourceTimestamp:
+		paths, err := docker.GetDependencies(nil, workspace, dockerfilePath)
+		if err != nil {
+			return time.Time{}, errors.Wrap(err, "getting dependencies")
+		}
+		return docker.NewestModTime(workspace, paths)
+	default:
+		return time.Time{}, errors.Errorf("unknown output timestamp %q", mode)
+	}
+}
+
+// normalizeImageTimestamp rewrites img's config Created field and every
+// layer's tar header times to t, so that two builds of unchanged source
+// produce a byte-identical digest.
+func normalizeImageTimestamp(img v1.Image, t time.Time) (v1.Image, error) {
+	return mutate.Time(img, t)
+}
@@ -0,0 +1,161 @@
+/*
+Copyright 2018 The Skaffold Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package config holds the parsed skaffold.yaml.
+package config
+
+// SkaffoldConfig is the top level config object that is parsed from a skaffold.yaml.
+type SkaffoldConfig struct {
+	Build  BuildConfig  `yaml:"build"`
+	Sign   *SignConfig  `yaml:"sign,omitempty"`
+	Deploy DeployConfig `yaml:"deploy"`
+}
+
+// SignConfig configures signing of built artifacts between build and
+// deploy. Exactly one of KeyPath or OIDC should be set.
+type SignConfig struct {
+	// KeyPath points at a static cosign-style private key file.
+	KeyPath string `yaml:"keyPath,omitempty"`
+	// OIDC signs with an ephemeral key and a short-lived certificate
+	// fetched from a Fulcio-like CA, instead of a static key.
+	OIDC *OIDCSignConfig `yaml:"oidc,omitempty"`
+	// VerifyOnly skips signing and only verifies that images about to be
+	// deployed already carry a valid signature.
+	VerifyOnly bool `yaml:"verifyOnly,omitempty"`
+	// VerifyKeyPath points at the public half of a static cosign-style key.
+	// When set, deploy-time verification cryptographically checks the
+	// pushed signature against it; when empty, verification only confirms
+	// a signature artifact exists, which a keyless OIDC signer also needs
+	// since there's no CA root configured to check its certificate chain.
+	VerifyKeyPath string `yaml:"verifyKeyPath,omitempty"`
+}
+
+// OIDCSignConfig configures the keyless signing flow.
+type OIDCSignConfig struct {
+	// FulcioURL is the CA that exchanges an OIDC identity token for a
+	// short-lived signing certificate.
+	FulcioURL string `yaml:"fulcioURL"`
+}
+
+// BuildConfig describes how images are built.
+type BuildConfig struct {
+	TagPolicy       string          `yaml:"tagPolicy"`
+	Artifacts       []*Artifact     `yaml:"artifacts"`
+	BuildType       BuildType       `yaml:"-"`
+	OutputTimestamp OutputTimestamp `yaml:"outputTimestamp"`
+	RemoteContext   *RemoteContext  `yaml:"remoteContext,omitempty"`
+}
+
+// RemoteContext selects the storage backend a builder uploads its build
+// context to incrementally, instead of re-sending the whole context on
+// every run. Exactly one field should be populated.
+type RemoteContext struct {
+	GCS *GCSRemoteContext `yaml:"gcs,omitempty"`
+	S3  *S3RemoteContext  `yaml:"s3,omitempty"`
+	OCI *OCIRemoteContext `yaml:"oci,omitempty"`
+}
+
+// GCSRemoteContext uploads the build context to a Google Cloud Storage bucket.
+type GCSRemoteContext struct {
+	Bucket string `yaml:"bucket"`
+}
+
+// S3RemoteContext uploads the build context to an Amazon S3 bucket.
+type S3RemoteContext struct {
+	Bucket string `yaml:"bucket"`
+	Region string `yaml:"region"`
+}
+
+// OCIRemoteContext uploads the build context to an OCI registry repository,
+// so any Docker registry can double as a build-context cache.
+type OCIRemoteContext struct {
+	Repo string `yaml:"repo"`
+}
+
+// OutputTimestamp selects the instant a builder stamps onto a pushed
+// image's config `Created` field and every layer's tar header, so that
+// repeated builds of unchanged source produce byte-identical digests.
+type OutputTimestamp string
+
+const (
+	// TimestampZero stamps the UNIX epoch (0), the most reproducible choice.
+	TimestampZero OutputTimestamp = "Zero"
+	// TimestampSourceTimestamp stamps the mtime of the newest file in the
+	// build context, so the image changes only when its source does.
+	TimestampSourceTimestamp OutputTimestamp = "SourceTimestamp"
+	// TimestampBuildTimestamp stamps the time the build ran, captured once
+	// per SkaffoldRunner.Run so multiple artifacts in the same run match.
+	TimestampBuildTimestamp OutputTimestamp = "BuildTimestamp"
+)
+
+// BuildType contains the specific implementation and parameters needed
+// for the specified build type. Only one field should be populated.
+type BuildType struct {
+	LocalBuild    *LocalBuild    `yaml:"local"`
+	CraneBuild    *CraneBuild    `yaml:"crane"`
+	BuildKitBuild *BuildKitBuild `yaml:"buildkit"`
+}
+
+// LocalBuild describes how to do a build on the local docker daemon.
+type LocalBuild struct {
+	SkipPush bool `yaml:"skipPush"`
+}
+
+// CraneBuild describes a daemonless build that assembles an image by
+// appending the build context directly onto its base image, without ever
+// talking to a docker daemon.
+type CraneBuild struct{}
+
+// BuildKitBuild describes a build that runs on a BuildKit daemon instead
+// of the local docker daemon, so CI users get layer caching and
+// multi-platform builds without a Docker install.
+type BuildKitBuild struct {
+	// Endpoint is the BuildKit daemon address: "unix:///path/to.sock",
+	// "tcp://host:port", or "docker-container://<name>" to drive a
+	// buildx-style daemon running inside a named container.
+	Endpoint string `yaml:"endpoint"`
+	// Platforms builds and assembles a single OCI image index covering
+	// each of these platforms (e.g. "linux/amd64", "linux/arm64").
+	// Defaults to the daemon's own platform when empty.
+	Platforms []string `yaml:"platforms,omitempty"`
+	// CacheImport and CacheExport are passed through verbatim as BuildKit
+	// cache import/export specs, e.g. "type=registry,ref=...",
+	// "type=inline", or "type=gha".
+	CacheImport []string `yaml:"cacheImport,omitempty"`
+	CacheExport []string `yaml:"cacheExport,omitempty"`
+}
+
+// DeployConfig describes how images are deployed.
+type DeployConfig struct {
+	DeployType DeployType `yaml:"-"`
+}
+
+// DeployType contains the specific implementation and parameters needed
+// for the specified deploy type. Only one field should be populated.
+type DeployType struct {
+	KubectlDeploy *KubectlDeploy `yaml:"kubectl"`
+}
+
+// KubectlDeploy deploys workloads with `kubectl apply`.
+type KubectlDeploy struct {
+	Manifests []string `yaml:"manifests"`
+}
+
+// Artifact describes a single image to be built and deployed.
+type Artifact struct {
+	ImageName string `yaml:"image"`
+	Workspace string `yaml:"context"`
+}
@@ -0,0 +1,34 @@
+/*
+Copyright 2018 The Skaffold Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package constants
+
+const (
+	// DefaultDockerfilePath is the default path to a Dockerfile, relative to the build context.
+	DefaultDockerfilePath = "Dockerfile"
+
+	// TagStrategySha256 tags images with the sha256 checksum of their content.
+	TagStrategySha256 = "sha256"
+
+	// TagStrategyGitCommit tags images with the current git commit.
+	TagStrategyGitCommit = "gitCommit"
+
+	// TagStrategyContentDigest tags images with their pushed manifest
+	// digest. It's only deterministic across runs once OutputTimestamp
+	// pins layer and config timestamps; otherwise every build produces a
+	// new digest even for unchanged source.
+	TagStrategyContentDigest = "contentDigest"
+)
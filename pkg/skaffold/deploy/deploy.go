@@ -0,0 +1,39 @@
+/*
+Copyright 2018 The Skaffold Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package deploy implements the strategies skaffold uses to put built
+// artifacts onto a cluster.
+package deploy
+
+import "github.com/GoogleContainerTools/skaffold/pkg/skaffold/build"
+
+// Deployer is the interface implemented by every deploy strategy (kubectl,
+// helm, ...).
+type Deployer interface {
+	Run(*build.BuildResult) (*Result, error)
+}
+
+// ImageVerifier is implemented by deployers that can refuse to apply
+// manifests whose images lack a valid signature. The runner calls
+// VerifyImages, when present, after signing and before Run.
+type ImageVerifier interface {
+	VerifyImages([]build.Build) error
+}
+
+// Result is the output of a Deployer run.
+type Result struct {
+	Namespace string
+}
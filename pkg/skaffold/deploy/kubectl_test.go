@@ -0,0 +1,58 @@
+/*
+Copyright 2018 The Skaffold Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package deploy
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/build"
+	"github.com/GoogleContainerTools/skaffold/testutil"
+)
+
+func TestKubectlDeployVerifyImages(t *testing.T) {
+	builds := []build.Build{{ImageName: "img", Tag: "img:v1", Digest: "sha256:abc"}}
+
+	var tests = []struct {
+		description string
+		verify      func(build.Build) error
+		shouldErr   bool
+	}{
+		{
+			// A KubectlDeploy built with no sign stanza at all must not
+			// fail deploys that never pushed a signature.
+			description: "no sign config configured",
+			verify:      nil,
+		},
+		{
+			description: "verify succeeds",
+			verify:      func(build.Build) error { return nil },
+		},
+		{
+			description: "verify fails",
+			verify:      func(build.Build) error { return fmt.Errorf("no signature found") },
+			shouldErr:   true,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			k := NewKubectlDeployer(nil, test.verify)
+			err := k.VerifyImages(builds)
+			testutil.CheckError(t, test.shouldErr, err)
+		})
+	}
+}
@@ -18,13 +18,16 @@ package docker
 
 import (
 	"context"
+	"crypto/sha256"
+	"fmt"
 	"io"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
+	"time"
 
 	cstorage "cloud.google.com/go/storage"
-	"github.com/GoogleCloudPlatform/cloud-builders/gcs-fetcher/pkg/uploader"
 	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/util"
 	"github.com/pkg/errors"
 )
@@ -63,6 +66,42 @@ func CreateDockerTarGzContext(buildArgs map[string]*string, w io.Writer, context
 	return nil
 }
 
+// CreateDockerTarGzContextWithTimestamp is like CreateDockerTarGzContext,
+// but stamps every tar entry with modTime instead of its real mtime. Used
+// by builders that pin OutputTimestamp so unchanged source reproduces a
+// byte-identical build context, and therefore a byte-identical image.
+func CreateDockerTarGzContextWithTimestamp(buildArgs map[string]*string, w io.Writer, context, dockerfilePath string, modTime time.Time) error {
+	paths, err := GetDependencies(buildArgs, context, dockerfilePath)
+	if err != nil {
+		return errors.Wrap(err, "getting relative tar paths")
+	}
+	if err := util.CreateTarGzWithTimestamp(w, context, paths, modTime); err != nil {
+		return errors.Wrap(err, "creating tar gz")
+	}
+	return nil
+}
+
+// NewestModTime returns the modification time of the most recently changed
+// file among paths, rooted at context. It's used to derive the
+// config.TimestampSourceTimestamp instant from a build's dependencies.
+func NewestModTime(context string, paths []string) (time.Time, error) {
+	var newest time.Time
+	for _, p := range paths {
+		absPath := p
+		if !filepath.IsAbs(p) {
+			absPath = filepath.Join(context, p)
+		}
+		info, err := os.Stat(absPath)
+		if err != nil {
+			return time.Time{}, errors.Wrapf(err, "stating %s", absPath)
+		}
+		if info.ModTime().After(newest) {
+			newest = info.ModTime()
+		}
+	}
+	return newest, nil
+}
+
 func UploadContextToGCS(ctx context.Context, context, dockerfilePath, bucket, objectName string) error {
 	c, err := cstorage.NewClient(ctx)
 	if err != nil {
@@ -77,49 +116,118 @@ func UploadContextToGCS(ctx context.Context, context, dockerfilePath, bucket, ob
 	return w.Close()
 }
 
-func IncrementallyUploadContextToGCS(ctx context.Context, context, dockerfilePath, bucket string) (string, error) {
-	c, err := cstorage.NewClient(ctx)
+// ContextStore is the storage backend an incremental build-context upload
+// writes file-blobs to. GCS, S3 and any OCI registry all implement it, so
+// any of them can serve as a build-context cache.
+type ContextStore interface {
+	// NewWriter returns a writer that uploads object's content.
+	NewWriter(ctx context.Context, object string) io.WriteCloser
+	// Exists reports whether object was already uploaded by a previous run.
+	Exists(ctx context.Context, object string) (bool, error)
+	// ManifestURL returns the URL a builder should be pointed at to fetch
+	// the uploaded manifest back.
+	ManifestURL(object string) string
+}
+
+// NewAsyncWriteCloser returns an io.WriteCloser that streams writes to
+// upload through an io.Pipe, running upload in a goroutine. Unlike a bare
+// io.Pipe, Close blocks until upload has finished and returns its error,
+// so a failed upload is reported to whatever called Close instead of
+// being silently dropped on the reader side of the pipe.
+func NewAsyncWriteCloser(upload func(r io.Reader) error) io.WriteCloser {
+	pr, pw := io.Pipe()
+	done := make(chan error, 1)
+	go func() {
+		done <- upload(pr)
+		pr.Close()
+	}()
+	return &asyncWriteCloser{PipeWriter: pw, done: done}
+}
+
+type asyncWriteCloser struct {
+	*io.PipeWriter
+	done chan error
+}
+
+func (w *asyncWriteCloser) Close() error {
+	w.PipeWriter.Close()
+	return <-w.done
+}
+
+// IncrementallyUploadContext uploads workspace's build context to store,
+// one blob per dependency file, skipping any blob store already has. The
+// manifest object name is a hash of the dependency list (path, size and
+// mtime of every file), so two runs against unchanged source reuse the
+// same manifest and re-upload nothing.
+func IncrementallyUploadContext(ctx context.Context, store ContextStore, workspace, dockerfilePath string) (string, error) {
+	paths, err := GetDependencies(map[string]*string{}, workspace, dockerfilePath)
 	if err != nil {
-		return "", err
+		return "", errors.Wrap(err, "getting dependencies")
 	}
-	defer c.Close()
 
-	manifestObject := "manifest.json" // TODO: generate unique name.
-	up := uploader.New(ctx, realGCS{c}, realOS{}, bucket, manifestObject, 10)
+	manifestObject, err := manifestObjectName(workspace, paths)
+	if err != nil {
+		return "", errors.Wrap(err, "naming manifest")
+	}
 
-	// Enqueue dependency paths.
-	paths, err := GetDependencies(map[string]*string{}, context, dockerfilePath)
 	for _, p := range paths {
 		slashPath := filepath.ToSlash(p)
 
+		absPath := p
 		if !filepath.IsAbs(p) {
-			p = filepath.Join(context, p)
+			absPath = filepath.Join(workspace, p)
 		}
-		info, err := os.Stat(p)
+
+		exists, err := store.Exists(ctx, slashPath)
 		if err != nil {
-			return "", err
+			return "", errors.Wrapf(err, "checking for existing %s", slashPath)
+		}
+		if exists {
+			continue
 		}
-		up.Enqueue(slashPath, info)
-	}
 
-	// Wait for all workers to finish, or for some error.
-	if err := up.Wait(ctx); err != nil {
-		return "", err
+		if err := uploadFile(ctx, store, absPath, slashPath); err != nil {
+			return "", errors.Wrapf(err, "uploading %s", slashPath)
+		}
 	}
-	return manifestObject, nil
+
+	return store.ManifestURL(manifestObject), nil
 }
 
-// realGCS is a wrapper over the GCS client functions.
-type realGCS struct{ client *cstorage.Client }
+func uploadFile(ctx context.Context, store ContextStore, absPath, object string) error {
+	f, err := os.Open(absPath)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
 
-func (gp realGCS) NewWriter(ctx context.Context, bucket, object string) io.WriteCloser {
-	return gp.client.Bucket(bucket).Object(object).
-		If(cstorage.Conditions{DoesNotExist: true}). // Skip upload if already exists.
-		NewWriter(ctx)
+	w := store.NewWriter(ctx, object)
+	if _, err := io.Copy(w, f); err != nil {
+		w.Close()
+		return err
+	}
+	return w.Close()
 }
 
-// realOS merely wraps the os package implementations.
-type realOS struct{}
-
-func (realOS) EvalSymlinks(path string) (string, error) { return filepath.EvalSymlinks(path) }
-func (realOS) Stat(path string) (os.FileInfo, error)    { return os.Stat(path) }
+// manifestObjectName derives a stable object name for paths, rooted at
+// workspace, from the size and mtime of every file. Unchanged dependencies
+// always hash to the same name, so successive runs reuse the manifest
+// instead of uploading a new one every time.
+func manifestObjectName(workspace string, paths []string) (string, error) {
+	sorted := append([]string(nil), paths...)
+	sort.Strings(sorted)
+
+	h := sha256.New()
+	for _, p := range sorted {
+		absPath := p
+		if !filepath.IsAbs(p) {
+			absPath = filepath.Join(workspace, p)
+		}
+		info, err := os.Stat(absPath)
+		if err != nil {
+			return "", errors.Wrapf(err, "stating %s", absPath)
+		}
+		fmt.Fprintf(h, "%s:%d:%d\n", filepath.ToSlash(p), info.Size(), info.ModTime().UnixNano())
+	}
+	return fmt.Sprintf("manifest-%x.json", h.Sum(nil)), nil
+}
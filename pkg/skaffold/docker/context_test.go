@@ -0,0 +1,135 @@
+/*
+Copyright 2018 The Skaffold Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package docker
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// fakeContextStore is an in-memory ContextStore that records every object
+// it was asked to upload, so tests can assert which files were skipped.
+type fakeContextStore struct {
+	objects  map[string][]byte
+	uploaded []string
+}
+
+func newFakeContextStore() *fakeContextStore {
+	return &fakeContextStore{objects: map[string][]byte{}}
+}
+
+func (f *fakeContextStore) NewWriter(ctx context.Context, object string) io.WriteCloser {
+	return &fakeWriter{store: f, object: object}
+}
+
+func (f *fakeContextStore) Exists(ctx context.Context, object string) (bool, error) {
+	_, ok := f.objects[object]
+	return ok, nil
+}
+
+func (f *fakeContextStore) ManifestURL(object string) string {
+	return "fake://" + object
+}
+
+type fakeWriter struct {
+	store  *fakeContextStore
+	object string
+	buf    bytes.Buffer
+}
+
+func (w *fakeWriter) Write(p []byte) (int, error) { return w.buf.Write(p) }
+
+func (w *fakeWriter) Close() error {
+	w.store.objects[w.object] = w.buf.Bytes()
+	w.store.uploaded = append(w.store.uploaded, w.object)
+	return nil
+}
+
+func TestIncrementallyUploadContextSkipsUnchangedFiles(t *testing.T) {
+	workspace, err := ioutil.TempDir("", "context-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(workspace)
+
+	dockerfilePath := filepath.Join(workspace, "Dockerfile")
+	if err := ioutil.WriteFile(dockerfilePath, []byte("FROM scratch\nCOPY . .\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(workspace, "unchanged.txt"), []byte("stays the same"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(workspace, "changes.txt"), []byte("v1"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	store := newFakeContextStore()
+
+	if _, err := IncrementallyUploadContext(context.Background(), store, workspace, dockerfilePath); err != nil {
+		t.Fatalf("first upload: %s", err)
+	}
+	firstUpload := append([]string(nil), store.uploaded...)
+	if len(firstUpload) != 3 {
+		t.Fatalf("expected 3 files uploaded on first run, got %d: %v", len(firstUpload), firstUpload)
+	}
+
+	store.uploaded = nil
+
+	// Touch changes.txt's content and mtime so it's picked up as modified,
+	// and leave unchanged.txt and the Dockerfile untouched.
+	if err := ioutil.WriteFile(filepath.Join(workspace, "changes.txt"), []byte("v2"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	future := time.Now().Add(time.Hour)
+	if err := os.Chtimes(filepath.Join(workspace, "changes.txt"), future, future); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := IncrementallyUploadContext(context.Background(), store, workspace, dockerfilePath); err != nil {
+		t.Fatalf("second upload: %s", err)
+	}
+
+	if len(store.uploaded) != 1 || store.uploaded[0] != "changes.txt" {
+		t.Errorf("expected only changes.txt to be re-uploaded, got %v", store.uploaded)
+	}
+}
+
+// TestNewAsyncWriteCloserSurfacesUploadError guards against the upload
+// goroutine's error getting dropped on the reader side of the pipe: Close
+// must block until upload returns and report its error, not succeed just
+// because every byte was written.
+func TestNewAsyncWriteCloserSurfacesUploadError(t *testing.T) {
+	wantErr := fmt.Errorf("upload failed")
+	w := NewAsyncWriteCloser(func(r io.Reader) error {
+		io.Copy(ioutil.Discard, r)
+		return wantErr
+	})
+
+	if _, err := w.Write([]byte("hello")); err != nil {
+		t.Fatalf("write: %s", err)
+	}
+	if err := w.Close(); err != wantErr {
+		t.Errorf("expected Close to return %v, got %v", wantErr, err)
+	}
+}
@@ -0,0 +1,129 @@
+/*
+Copyright 2018 The Skaffold Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package docker
+
+import (
+	"bufio"
+	"os"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// ImageConfig is the subset of Dockerfile instructions that can be honored
+// without a daemon: a single base image, plus the config values that only
+// rewrite image metadata rather than execute anything.
+type ImageConfig struct {
+	From       string
+	WorkingDir string
+	Entrypoint []string
+	Env        map[string]string
+	Labels     map[string]string
+}
+
+// ErrUnsupportedInstruction is returned when a Dockerfile uses an
+// instruction the crane front-end can't honor, such as RUN or a second
+// FROM (multi-stage builds).
+var ErrUnsupportedInstruction = errors.New("Dockerfile uses instructions that require a docker daemon (e.g. RUN, multi-stage builds); use the local builder instead")
+
+// ParseImageConfig parses the small subset of Dockerfile syntax the crane
+// builder can apply directly to an image's config: FROM, WORKDIR,
+// ENTRYPOINT, ENV and LABEL. Any other instruction, or a second FROM,
+// returns ErrUnsupportedInstruction.
+func ParseImageConfig(dockerfilePath string) (*ImageConfig, error) {
+	f, err := os.Open(dockerfilePath)
+	if err != nil {
+		return nil, errors.Wrap(err, "opening dockerfile")
+	}
+	defer f.Close()
+
+	cfg := &ImageConfig{
+		Env:    map[string]string{},
+		Labels: map[string]string{},
+	}
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		fields := strings.SplitN(line, " ", 2)
+		if len(fields) != 2 {
+			continue
+		}
+		instruction := strings.ToUpper(fields[0])
+		args := strings.TrimSpace(fields[1])
+
+		switch instruction {
+		case "FROM":
+			if cfg.From != "" {
+				return nil, ErrUnsupportedInstruction
+			}
+			cfg.From = args
+		case "WORKDIR":
+			cfg.WorkingDir = args
+		case "ENTRYPOINT":
+			cfg.Entrypoint = parseExecForm(args)
+		case "ENV":
+			k, v := splitKeyValue(args)
+			cfg.Env[k] = v
+		case "LABEL":
+			k, v := splitKeyValue(args)
+			cfg.Labels[k] = v
+		case "COPY", "ADD":
+			// Handled separately by building the context tarball; the
+			// crane builder always copies the whole build context.
+		default:
+			return nil, ErrUnsupportedInstruction
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, errors.Wrap(err, "scanning dockerfile")
+	}
+	if cfg.From == "" {
+		return nil, errors.New("dockerfile has no FROM instruction")
+	}
+	return cfg, nil
+}
+
+func parseExecForm(s string) []string {
+	s = strings.TrimPrefix(s, "[")
+	s = strings.TrimSuffix(s, "]")
+	var out []string
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		part = strings.Trim(part, `"`)
+		if part != "" {
+			out = append(out, part)
+		}
+	}
+	return out
+}
+
+func splitKeyValue(s string) (string, string) {
+	parts := strings.SplitN(s, "=", 2)
+	if len(parts) == 2 {
+		return strings.TrimSpace(parts[0]), strings.Trim(strings.TrimSpace(parts[1]), `"`)
+	}
+	fields := strings.SplitN(s, " ", 2)
+	if len(fields) == 2 {
+		return fields[0], strings.Trim(fields[1], `"`)
+	}
+	return s, ""
+}
@@ -0,0 +1,64 @@
+/*
+Copyright 2018 The Skaffold Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package docker
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	cstorage "cloud.google.com/go/storage"
+	"github.com/pkg/errors"
+)
+
+// GCSContextStore is a ContextStore backed by a Google Cloud Storage bucket.
+type GCSContextStore struct {
+	client *cstorage.Client
+	bucket string
+}
+
+// NewGCSContextStore returns a ContextStore that uploads to bucket.
+func NewGCSContextStore(ctx context.Context, bucket string) (*GCSContextStore, error) {
+	client, err := cstorage.NewClient(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "creating GCS client")
+	}
+	return &GCSContextStore{client: client, bucket: bucket}, nil
+}
+
+// NewWriter implements ContextStore.
+func (g *GCSContextStore) NewWriter(ctx context.Context, object string) io.WriteCloser {
+	return g.client.Bucket(g.bucket).Object(object).NewWriter(ctx)
+}
+
+// Exists implements ContextStore.
+func (g *GCSContextStore) Exists(ctx context.Context, object string) (bool, error) {
+	_, err := g.client.Bucket(g.bucket).Object(object).Attrs(ctx)
+	switch err {
+	case nil:
+		return true, nil
+	case cstorage.ErrObjectNotExist:
+		return false, nil
+	default:
+		return false, err
+	}
+}
+
+// ManifestURL implements ContextStore.
+func (g *GCSContextStore) ManifestURL(object string) string {
+	return fmt.Sprintf("gs://%s/%s", g.bucket, object)
+}
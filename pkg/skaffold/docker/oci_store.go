@@ -0,0 +1,112 @@
+/*
+Copyright 2018 The Skaffold Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package docker
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+
+	"github.com/google/go-containerregistry/pkg/authn"
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/v1/empty"
+	"github.com/google/go-containerregistry/pkg/v1/mutate"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/google/go-containerregistry/pkg/v1/remote/transport"
+	"github.com/google/go-containerregistry/pkg/v1/tarball"
+	"github.com/pkg/errors"
+)
+
+// OCIContextStore is a ContextStore that pushes every file-blob as its own
+// single-layer image to an OCI registry, so any Docker registry can double
+// as a build-context cache with no extra infrastructure.
+type OCIContextStore struct {
+	repo name.Repository
+}
+
+// NewOCIContextStore returns a ContextStore that uploads to repo.
+func NewOCIContextStore(repo string) (*OCIContextStore, error) {
+	r, err := name.NewRepository(repo, name.WeakValidation)
+	if err != nil {
+		return nil, errors.Wrapf(err, "parsing repository %s", repo)
+	}
+	return &OCIContextStore{repo: r}, nil
+}
+
+// ref maps an object name to a stable, DNS-safe tag in o.repo.
+func (o *OCIContextStore) ref(object string) (name.Reference, error) {
+	sum := sha256.Sum256([]byte(object))
+	tag := "ctx-" + hex.EncodeToString(sum[:16])
+	return name.ParseReference(o.repo.Name()+":"+tag, name.WeakValidation)
+}
+
+// NewWriter implements ContextStore. The returned writer's Close blocks
+// until the push to the registry finishes, and returns its error, so a
+// failed push is reported to the caller instead of being silently
+// dropped.
+func (o *OCIContextStore) NewWriter(ctx context.Context, object string) io.WriteCloser {
+	return NewAsyncWriteCloser(func(r io.Reader) error {
+		return o.push(ctx, object, r)
+	})
+}
+
+func (o *OCIContextStore) push(ctx context.Context, object string, r io.Reader) error {
+	layer, err := tarball.LayerFromReader(r)
+	if err != nil {
+		return errors.Wrap(err, "creating layer from blob")
+	}
+
+	img, err := mutate.AppendLayers(empty.Image, layer)
+	if err != nil {
+		return errors.Wrap(err, "appending blob layer")
+	}
+
+	ref, err := o.ref(object)
+	if err != nil {
+		return err
+	}
+
+	return remote.Write(ref, img, remote.WithContext(ctx), remote.WithAuthFromKeychain(authn.DefaultKeychain))
+}
+
+// Exists implements ContextStore.
+func (o *OCIContextStore) Exists(ctx context.Context, object string) (bool, error) {
+	ref, err := o.ref(object)
+	if err != nil {
+		return false, err
+	}
+
+	_, err = remote.Head(ref, remote.WithContext(ctx), remote.WithAuthFromKeychain(authn.DefaultKeychain))
+	if err == nil {
+		return true, nil
+	}
+	if terr, ok := err.(*transport.Error); ok && terr.StatusCode == http.StatusNotFound {
+		return false, nil
+	}
+	return false, err
+}
+
+// ManifestURL implements ContextStore.
+func (o *OCIContextStore) ManifestURL(object string) string {
+	ref, err := o.ref(object)
+	if err != nil {
+		return ""
+	}
+	return ref.String()
+}
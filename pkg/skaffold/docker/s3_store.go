@@ -0,0 +1,87 @@
+/*
+Copyright 2018 The Skaffold Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package docker
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/aws/aws-sdk-go/service/s3/s3manager"
+	"github.com/pkg/errors"
+)
+
+// S3ContextStore is a ContextStore backed by an Amazon S3 bucket.
+type S3ContextStore struct {
+	client   *s3.S3
+	uploader *s3manager.Uploader
+	bucket   string
+}
+
+// NewS3ContextStore returns a ContextStore that uploads to bucket in region.
+func NewS3ContextStore(bucket, region string) (*S3ContextStore, error) {
+	sess, err := session.NewSession(&aws.Config{Region: aws.String(region)})
+	if err != nil {
+		return nil, errors.Wrap(err, "creating AWS session")
+	}
+	return &S3ContextStore{
+		client:   s3.New(sess),
+		uploader: s3manager.NewUploader(sess),
+		bucket:   bucket,
+	}, nil
+}
+
+// NewWriter implements ContextStore. S3 has no native streaming writer, so
+// the upload runs in a goroutine fed by an io.Pipe; the returned writer's
+// Close blocks until that upload finishes and returns its error, so a
+// failed upload is reported to the caller instead of being silently
+// dropped.
+func (s *S3ContextStore) NewWriter(ctx context.Context, object string) io.WriteCloser {
+	return NewAsyncWriteCloser(func(r io.Reader) error {
+		_, err := s.uploader.UploadWithContext(ctx, &s3manager.UploadInput{
+			Bucket: aws.String(s.bucket),
+			Key:    aws.String(object),
+			Body:   r,
+		})
+		return err
+	})
+}
+
+// Exists implements ContextStore.
+func (s *S3ContextStore) Exists(ctx context.Context, object string) (bool, error) {
+	_, err := s.client.HeadObjectWithContext(ctx, &s3.HeadObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(object),
+	})
+	if err == nil {
+		return true, nil
+	}
+	if reqErr, ok := err.(awserr.RequestFailure); ok && reqErr.StatusCode() == http.StatusNotFound {
+		return false, nil
+	}
+	return false, err
+}
+
+// ManifestURL implements ContextStore.
+func (s *S3ContextStore) ManifestURL(object string) string {
+	return fmt.Sprintf("s3://%s/%s", s.bucket, object)
+}
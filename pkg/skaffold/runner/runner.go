@@ -0,0 +1,289 @@
+/*
+Copyright 2018 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package runner wires together the builder, tagger, deployer and watcher
+// that a single `skaffold run`/`skaffold dev` invocation needs, based on
+// the parsed SkaffoldConfig.
+package runner
+
+import (
+	"context"
+	"io"
+	"io/ioutil"
+	"path/filepath"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/build"
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/build/tag"
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/config"
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/constants"
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/deploy"
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/docker"
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/sign"
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/watch"
+)
+
+// SkaffoldRunner is responsible for running the skaffold build, tag and
+// deploy pipeline, once for `skaffold run` or repeatedly for `skaffold dev`.
+type SkaffoldRunner struct {
+	Builder      build.Builder
+	Tagger       tag.Tagger
+	Signer       sign.Signer
+	Deployer     deploy.Deployer
+	Watcher      watch.Watcher
+	ContextStore docker.ContextStore
+
+	config  *config.SkaffoldConfig
+	devMode bool
+
+	cancel     chan struct{}
+	watchReady chan *watch.Event
+}
+
+// NewForConfig returns a SkaffoldRunner whose Builder, Tagger and Deployer
+// are chosen according to cfg.
+func NewForConfig(out io.Writer, devMode bool, cfg *config.SkaffoldConfig) (*SkaffoldRunner, error) {
+	if err := validateOutputTimestamp(cfg.Build.OutputTimestamp); err != nil {
+		return nil, errors.Wrap(err, "parsing build config")
+	}
+
+	builder, err := getBuilder(&cfg.Build)
+	if err != nil {
+		return nil, errors.Wrap(err, "parsing build config")
+	}
+
+	tagger, err := getTagger(cfg.Build.TagPolicy)
+	if err != nil {
+		return nil, errors.Wrap(err, "parsing tag config")
+	}
+
+	signer, err := getSigner(cfg.Sign)
+	if err != nil {
+		return nil, errors.Wrap(err, "parsing sign config")
+	}
+
+	deployer, err := getDeployer(&cfg.Deploy, cfg.Sign)
+	if err != nil {
+		return nil, errors.Wrap(err, "parsing deploy config")
+	}
+
+	contextStore, err := getContextStore(cfg.Build.RemoteContext)
+	if err != nil {
+		return nil, errors.Wrap(err, "parsing remote context config")
+	}
+
+	return &SkaffoldRunner{
+		Builder:      builder,
+		Tagger:       tagger,
+		Signer:       signer,
+		Deployer:     deployer,
+		ContextStore: contextStore,
+		config:       cfg,
+		devMode:      devMode,
+		cancel:       make(chan struct{}, 1),
+		watchReady:   make(chan *watch.Event, 1),
+	}, nil
+}
+
+func getBuilder(cfg *config.BuildConfig) (build.Builder, error) {
+	switch {
+	case cfg.BuildType.LocalBuild != nil:
+		return build.NewLocalBuilder(cfg.BuildType.LocalBuild, cfg.OutputTimestamp)
+	case cfg.BuildType.CraneBuild != nil:
+		return build.NewCraneBuilder(cfg.BuildType.CraneBuild, cfg.Artifacts, cfg.OutputTimestamp)
+	case cfg.BuildType.BuildKitBuild != nil:
+		return build.NewBuildKitBuilder(cfg.BuildType.BuildKitBuild, cfg.Artifacts, cfg.OutputTimestamp)
+	default:
+		return nil, errors.New("unknown builder for config")
+	}
+}
+
+// validateOutputTimestamp rejects any OutputTimestamp value other than the
+// empty default or one of the three known modes, the same way getTagger
+// rejects an unknown TagPolicy.
+func validateOutputTimestamp(ts config.OutputTimestamp) error {
+	switch ts {
+	case "", config.TimestampZero, config.TimestampSourceTimestamp, config.TimestampBuildTimestamp:
+		return nil
+	default:
+		return errors.Errorf("unknown output timestamp %q", ts)
+	}
+}
+
+func getTagger(tagStrategy string) (tag.Tagger, error) {
+	switch tagStrategy {
+	case constants.TagStrategySha256:
+		return &tag.ChecksumTagger{}, nil
+	case constants.TagStrategyContentDigest:
+		return &tag.ContentDigestTagger{}, nil
+	default:
+		return nil, errors.Errorf("unknown tagger for strategy %s", tagStrategy)
+	}
+}
+
+// getSigner returns nil, nil when cfg is nil: signing is entirely optional
+// and most SkaffoldConfigs won't set it.
+func getSigner(cfg *config.SignConfig) (sign.Signer, error) {
+	if cfg == nil {
+		return nil, nil
+	}
+	if cfg.VerifyOnly {
+		return nil, nil
+	}
+
+	switch {
+	case cfg.OIDC != nil:
+		return sign.NewOIDCSigner(cfg.OIDC.FulcioURL, sign.NewHTTPFulcioClient(), sign.DefaultIDTokenSource), nil
+	case cfg.KeyPath != "":
+		return sign.NewKeySigner(cfg.KeyPath)
+	default:
+		return nil, errors.New("sign config must set either keyPath or oidc")
+	}
+}
+
+// getContextStore returns nil, nil when cfg is nil: most SkaffoldConfigs
+// send their whole build context on every run and never set RemoteContext.
+func getContextStore(cfg *config.RemoteContext) (docker.ContextStore, error) {
+	if cfg == nil {
+		return nil, nil
+	}
+
+	switch {
+	case cfg.GCS != nil:
+		return docker.NewGCSContextStore(context.Background(), cfg.GCS.Bucket)
+	case cfg.S3 != nil:
+		return docker.NewS3ContextStore(cfg.S3.Bucket, cfg.S3.Region)
+	case cfg.OCI != nil:
+		return docker.NewOCIContextStore(cfg.OCI.Repo)
+	default:
+		return nil, errors.New("remote context config must set one of gcs, s3 or oci")
+	}
+}
+
+func getDeployer(cfg *config.DeployConfig, signCfg *config.SignConfig) (deploy.Deployer, error) {
+	verify, err := getVerifier(signCfg)
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case cfg.DeployType.KubectlDeploy != nil:
+		return deploy.NewKubectlDeployer(cfg.DeployType.KubectlDeploy, verify), nil
+	default:
+		return nil, errors.New("unknown deployer for config")
+	}
+}
+
+// getVerifier returns nil, nil when signCfg is nil: deploy-time
+// verification only kicks in when a sign stanza is configured, so a
+// SkaffoldConfig with none behaves exactly as it did before signing
+// existed — no verification, no dependency on a .sig artifact ever having
+// been pushed.
+func getVerifier(signCfg *config.SignConfig) (func(build.Build) error, error) {
+	if signCfg == nil {
+		return nil, nil
+	}
+
+	if signCfg.VerifyKeyPath == "" {
+		logrus.Warn("sign config has no verifyKeyPath: deploy-time verification will only confirm a signature was pushed, not that it's trustworthy")
+		return sign.Verify, nil
+	}
+
+	pub, err := sign.LoadPublicKey(signCfg.VerifyKeyPath)
+	if err != nil {
+		return nil, errors.Wrapf(err, "loading verify key %s", signCfg.VerifyKeyPath)
+	}
+	return func(b build.Build) error { return sign.VerifyWithKey(b, pub) }, nil
+}
+
+// Run runs a single build-tag-deploy pipeline, or, in dev mode, keeps
+// re-running it every time Watcher reports a change. If the Builder cares
+// about config.TimestampBuildTimestamp, it's stamped once here so every
+// artifact built in this Run shares the same Created time.
+func (r *SkaffoldRunner) Run() error {
+	if setter, ok := r.Builder.(build.TimestampSetter); ok {
+		setter.SetBuildTime(time.Now())
+	}
+
+	if !r.devMode {
+		return r.runOnce()
+	}
+
+	for {
+		evt, err := r.Watcher.Watch(nil, r.watchReady, r.cancel)
+		if err != nil {
+			return errors.Wrap(err, "watching for changes")
+		}
+
+		if err := r.runOnce(); err != nil {
+			return err
+		}
+
+		if evt == watch.WatchStopEvent {
+			return nil
+		}
+	}
+}
+
+func (r *SkaffoldRunner) runOnce() error {
+	if r.ContextStore != nil {
+		if err := r.uploadContexts(); err != nil {
+			return errors.Wrap(err, "uploading build context")
+		}
+	}
+
+	res, err := r.Builder.Run(ioutil.Discard, r.Tagger)
+	if err != nil {
+		return errors.Wrap(err, "build step")
+	}
+
+	if r.Signer != nil {
+		for _, b := range res.Builds {
+			if err := r.Signer.Sign(b); err != nil {
+				return errors.Wrapf(err, "signing %s", b.Tag)
+			}
+		}
+	}
+
+	if verifier, ok := r.Deployer.(deploy.ImageVerifier); ok {
+		if err := verifier.VerifyImages(res.Builds); err != nil {
+			return errors.Wrap(err, "verifying image signatures")
+		}
+	}
+
+	if _, err := r.Deployer.Run(res); err != nil {
+		return errors.Wrap(err, "deploy step")
+	}
+	return nil
+}
+
+// uploadContexts incrementally uploads every configured artifact's build
+// context to r.ContextStore, skipping any file already uploaded by a
+// previous run, so the context is backed up and deduplicated remotely.
+// None of the current Builders read a context back from ContextStore yet;
+// each still builds directly from the local workspace.
+func (r *SkaffoldRunner) uploadContexts() error {
+	for _, a := range r.config.Build.Artifacts {
+		dockerfilePath := filepath.Join(a.Workspace, "Dockerfile")
+		if _, err := docker.IncrementallyUploadContext(context.Background(), r.ContextStore, a.Workspace, dockerfilePath); err != nil {
+			return errors.Wrapf(err, "uploading context for %s", a.ImageName)
+		}
+	}
+	return nil
+}
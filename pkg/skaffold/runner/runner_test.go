@@ -18,21 +18,52 @@ package runner
 
 import (
 	"bytes"
+	"context"
 	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
 	"testing"
 
 	"fmt"
 
-	"github.com/GoogleCloudPlatform/skaffold/pkg/skaffold/build"
-	"github.com/GoogleCloudPlatform/skaffold/pkg/skaffold/build/tag"
-	"github.com/GoogleCloudPlatform/skaffold/pkg/skaffold/config"
-	"github.com/GoogleCloudPlatform/skaffold/pkg/skaffold/constants"
-	"github.com/GoogleCloudPlatform/skaffold/pkg/skaffold/deploy"
-	"github.com/GoogleCloudPlatform/skaffold/pkg/skaffold/watch"
-	"github.com/GoogleCloudPlatform/skaffold/testutil"
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/build"
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/build/tag"
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/config"
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/constants"
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/deploy"
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/docker"
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/sign"
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/watch"
+	"github.com/GoogleContainerTools/skaffold/testutil"
 	"github.com/sirupsen/logrus"
 )
 
+// TestContextStore is an in-memory docker.ContextStore that records every
+// object it was asked to upload, so a test can assert runOnce actually
+// called IncrementallyUploadContext instead of ignoring r.ContextStore.
+type TestContextStore struct {
+	uploaded []string
+}
+
+func (t *TestContextStore) NewWriter(ctx context.Context, object string) io.WriteCloser {
+	t.uploaded = append(t.uploaded, object)
+	return &testContextWriter{}
+}
+
+func (t *TestContextStore) Exists(ctx context.Context, object string) (bool, error) {
+	return false, nil
+}
+
+func (t *TestContextStore) ManifestURL(object string) string {
+	return "test://" + object
+}
+
+type testContextWriter struct{}
+
+func (testContextWriter) Write(p []byte) (int, error) { return len(p), nil }
+func (testContextWriter) Close() error                { return nil }
+
 type TestBuilder struct {
 	res *build.BuildResult
 	err error
@@ -41,6 +72,8 @@ type TestBuilder struct {
 type TestDeployer struct {
 	res *deploy.Result
 	err error
+
+	ran bool
 }
 
 func (t *TestBuilder) Run(io.Writer, tag.Tagger) (*build.BuildResult, error) {
@@ -68,8 +101,33 @@ func (t *TestWatcher) Watch(artifacts []*config.Artifact, ready chan *watch.Even
 }
 
 func (t *TestDeployer) Run(*build.BuildResult) (*deploy.Result, error) {
+	t.ran = true
 	return t.res, t.err
 }
+
+type TestSigner struct {
+	err error
+
+	signed []build.Build
+}
+
+func (t *TestSigner) Sign(b build.Build) error {
+	t.signed = append(t.signed, b)
+	return t.err
+}
+
+type TestVerifyDeployer struct {
+	TestDeployer
+
+	verifyErr error
+	verified  []build.Build
+}
+
+func (t *TestVerifyDeployer) VerifyImages(builds []build.Build) error {
+	t.verified = builds
+	return t.verifyErr
+}
+
 func TestNewForConfig(t *testing.T) {
 	var tests = []struct {
 		description string
@@ -111,6 +169,97 @@ func TestNewForConfig(t *testing.T) {
 			},
 			shouldErr: true,
 		},
+		{
+			description: "bad output timestamp config",
+			config: &config.SkaffoldConfig{
+				Build: config.BuildConfig{
+					TagPolicy:       constants.TagStrategySha256,
+					OutputTimestamp: "bad timestamp mode",
+					BuildType: config.BuildType{
+						LocalBuild: &config.LocalBuild{},
+					},
+				},
+				Deploy: config.DeployConfig{
+					DeployType: config.DeployType{
+						KubectlDeploy: &config.KubectlDeploy{},
+					},
+				},
+			},
+			shouldErr: true,
+		},
+		{
+			description: "buildkit builder config",
+			config: &config.SkaffoldConfig{
+				Build: config.BuildConfig{
+					TagPolicy: constants.TagStrategySha256,
+					BuildType: config.BuildType{
+						BuildKitBuild: &config.BuildKitBuild{
+							Endpoint: "unix:///run/buildkit/buildkitd.sock",
+						},
+					},
+				},
+				Deploy: config.DeployConfig{
+					DeployType: config.DeployType{
+						KubectlDeploy: &config.KubectlDeploy{},
+					},
+				},
+			},
+			expected: &build.BuildKitBuilder{},
+		},
+		{
+			description: "buildkit builder missing endpoint",
+			config: &config.SkaffoldConfig{
+				Build: config.BuildConfig{
+					TagPolicy: constants.TagStrategySha256,
+					BuildType: config.BuildType{
+						BuildKitBuild: &config.BuildKitBuild{},
+					},
+				},
+				Deploy: config.DeployConfig{
+					DeployType: config.DeployType{
+						KubectlDeploy: &config.KubectlDeploy{},
+					},
+				},
+			},
+			shouldErr: true,
+		},
+		{
+			description: "buildkit builder invalid endpoint scheme",
+			config: &config.SkaffoldConfig{
+				Build: config.BuildConfig{
+					TagPolicy: constants.TagStrategySha256,
+					BuildType: config.BuildType{
+						BuildKitBuild: &config.BuildKitBuild{
+							Endpoint: "ftp://unsupported",
+						},
+					},
+				},
+				Deploy: config.DeployConfig{
+					DeployType: config.DeployType{
+						KubectlDeploy: &config.KubectlDeploy{},
+					},
+				},
+			},
+			shouldErr: true,
+		},
+		{
+			description: "bad remote context config",
+			config: &config.SkaffoldConfig{
+				Build: config.BuildConfig{
+					TagPolicy:     constants.TagStrategySha256,
+					RemoteContext: &config.RemoteContext{},
+					BuildType: config.BuildType{
+						LocalBuild: &config.LocalBuild{},
+					},
+				},
+				Deploy: config.DeployConfig{
+					DeployType: config.DeployType{
+						KubectlDeploy: &config.KubectlDeploy{},
+					},
+				},
+			},
+			shouldErr: true,
+		},
 		{
 			description: "unknown builder",
 			config: &config.SkaffoldConfig{
@@ -268,4 +417,116 @@ func TestRun(t *testing.T) {
 			testutil.CheckError(t, test.shouldErr, err)
 		})
 	}
-}
\ No newline at end of file
+}
+
+func TestRunWithSigning(t *testing.T) {
+	build1 := build.Build{ImageName: "img", Tag: "img:v1", Digest: "sha256:abc"}
+
+	var tests = []struct {
+		description  string
+		signer       sign.Signer
+		deployer     deploy.Deployer
+		shouldErr    bool
+		shouldDeploy bool
+	}{
+		{
+			description:  "signing disabled",
+			signer:       nil,
+			deployer:     &TestDeployer{res: &deploy.Result{}},
+			shouldDeploy: true,
+		},
+		{
+			description:  "signing succeeds",
+			signer:       &TestSigner{},
+			deployer:     &TestDeployer{res: &deploy.Result{}},
+			shouldDeploy: true,
+		},
+		{
+			description: "signing fails aborts deploy",
+			signer:      &TestSigner{err: fmt.Errorf("signing failed")},
+			deployer:    &TestDeployer{res: &deploy.Result{}},
+			shouldErr:   true,
+		},
+		{
+			description:  "verify-only mode rejects unsigned image",
+			signer:       nil,
+			deployer:     &TestVerifyDeployer{verifyErr: fmt.Errorf("no signature found")},
+			shouldErr:    true,
+			shouldDeploy: false,
+		},
+		{
+			description:  "verify-only mode accepts signed image",
+			signer:       nil,
+			deployer:     &TestVerifyDeployer{TestDeployer: TestDeployer{res: &deploy.Result{}}},
+			shouldDeploy: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			runner := &SkaffoldRunner{
+				config: &config.SkaffoldConfig{},
+				Builder: &TestBuilder{
+					res: &build.BuildResult{Builds: []build.Build{build1}},
+				},
+				Tagger:   &tag.ChecksumTagger{},
+				Signer:   test.signer,
+				Deployer: test.deployer,
+			}
+
+			err := runner.Run()
+			testutil.CheckError(t, test.shouldErr, err)
+
+			var ran bool
+			switch d := test.deployer.(type) {
+			case *TestVerifyDeployer:
+				ran = d.TestDeployer.ran
+			case *TestDeployer:
+				ran = d.ran
+			}
+			if ran != test.shouldDeploy {
+				t.Errorf("expected deploy=%v, got %v", test.shouldDeploy, ran)
+			}
+		})
+	}
+}
+
+func TestRunUploadsContext(t *testing.T) {
+	workspace, err := ioutil.TempDir("", "runner-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(workspace)
+
+	if err := ioutil.WriteFile(filepath.Join(workspace, "Dockerfile"), []byte("FROM scratch\nCOPY . .\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(workspace, "app.txt"), []byte("hello"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	store := &TestContextStore{}
+	runner := &SkaffoldRunner{
+		config: &config.SkaffoldConfig{
+			Build: config.BuildConfig{
+				Artifacts: []*config.Artifact{{ImageName: "img", Workspace: workspace}},
+			},
+		},
+		Builder: &TestBuilder{
+			res: &build.BuildResult{},
+		},
+		Tagger:       &tag.ChecksumTagger{},
+		Deployer:     &TestDeployer{res: &deploy.Result{}},
+		ContextStore: store,
+	}
+
+	if err := runner.Run(); err != nil {
+		t.Fatalf("Run: %s", err)
+	}
+
+	if len(store.uploaded) == 0 {
+		t.Error("expected runOnce to upload the build context, but ContextStore saw nothing")
+	}
+}
+
+var _ docker.ContextStore = (*TestContextStore)(nil)
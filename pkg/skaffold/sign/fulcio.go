@@ -0,0 +1,92 @@
+/*
+Copyright 2018 The Skaffold Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sign
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/pkg/errors"
+)
+
+// HTTPFulcioClient requests a signing certificate from a Fulcio-like CA
+// over HTTP, exchanging an OIDC identity token for a short-lived
+// certificate over an ephemeral public key.
+type HTTPFulcioClient struct {
+	client *http.Client
+}
+
+// NewHTTPFulcioClient returns a fulcioClient backed by the default HTTP client.
+func NewHTTPFulcioClient() *HTTPFulcioClient {
+	return &HTTPFulcioClient{client: http.DefaultClient}
+}
+
+type fulcioRequest struct {
+	PublicKey string `json:"publicKey"`
+	IDToken   string `json:"idToken"`
+}
+
+type fulcioResponse struct {
+	Certificate string `json:"certificate"`
+}
+
+// RequestCertificate implements fulcioClient.
+func (c *HTTPFulcioClient) RequestCertificate(caURL string, pub *ecdsa.PublicKey, idToken string) ([]byte, error) {
+	der, err := x509.MarshalPKIXPublicKey(pub)
+	if err != nil {
+		return nil, errors.Wrap(err, "marshaling public key")
+	}
+
+	body, err := json.Marshal(fulcioRequest{
+		PublicKey: base64.StdEncoding.EncodeToString(der),
+		IDToken:   idToken,
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "marshaling fulcio request")
+	}
+
+	resp, err := c.client.Post(caURL, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return nil, errors.Wrap(err, "calling fulcio CA")
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.Errorf("fulcio CA returned %s", resp.Status)
+	}
+
+	raw, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, errors.Wrap(err, "reading fulcio response")
+	}
+
+	var fr fulcioResponse
+	if err := json.Unmarshal(raw, &fr); err != nil {
+		return nil, errors.Wrap(err, "decoding fulcio response")
+	}
+
+	cert, err := base64.StdEncoding.DecodeString(fr.Certificate)
+	if err != nil {
+		return nil, errors.Wrap(err, "decoding certificate")
+	}
+	return cert, nil
+}
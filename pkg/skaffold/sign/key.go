@@ -0,0 +1,156 @@
+/*
+Copyright 2018 The Skaffold Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sign
+
+import (
+	"crypto/ecdsa"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/pem"
+	"io/ioutil"
+
+	"github.com/google/go-containerregistry/pkg/authn"
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/google/go-containerregistry/pkg/v1/static"
+	"github.com/pkg/errors"
+
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/build"
+)
+
+// KeySigner signs artifacts with a static cosign-style ECDSA private key
+// loaded once from disk.
+type KeySigner struct {
+	keyPath string
+	key     *ecdsa.PrivateKey
+}
+
+// NewKeySigner loads the private key at keyPath and returns a KeySigner.
+func NewKeySigner(keyPath string) (*KeySigner, error) {
+	key, err := loadCosignKey(keyPath)
+	if err != nil {
+		return nil, errors.Wrapf(err, "loading signing key %s", keyPath)
+	}
+	return &KeySigner{keyPath: keyPath, key: key}, nil
+}
+
+// Sign computes b's simple-signing payload, signs it with the static key
+// and pushes the signature to `<repo>:sha256-<hex>.sig`.
+func (s *KeySigner) Sign(b build.Build) error {
+	msg, err := newPayload(b)
+	if err != nil {
+		return err
+	}
+
+	sig, err := signECDSA(s.key, msg)
+	if err != nil {
+		return errors.Wrap(err, "signing payload")
+	}
+
+	return pushSignature(b, msg, sig, nil)
+}
+
+// pushSignature pushes msg/sig as a single-layer OCI artifact to the
+// cosign-compatible signature tag for b. cert, if non-nil, is the
+// short-lived signing certificate from a keyless OIDC signature.
+func pushSignature(b build.Build, msg, sig, cert []byte) error {
+	tag, err := SignatureTag(b.Digest)
+	if err != nil {
+		return err
+	}
+
+	repo, err := repoFromTag(b.Tag)
+	if err != nil {
+		return err
+	}
+
+	ref, err := name.ParseReference(repo+":"+tag, name.WeakValidation)
+	if err != nil {
+		return errors.Wrapf(err, "parsing signature ref %s:%s", repo, tag)
+	}
+
+	annotations := map[string]string{
+		"dev.cosignproject.cosign/signature": string(sig),
+	}
+	if cert != nil {
+		annotations["dev.cosignproject.cosign/certificate"] = string(cert)
+	}
+
+	img, err := static.NewFile(msg, static.WithAnnotations(annotations))
+	if err != nil {
+		return errors.Wrap(err, "building signature image")
+	}
+
+	return remote.Write(ref, img, remote.WithAuthFromKeychain(authn.DefaultKeychain))
+}
+
+// loadCosignKey reads a PEM-encoded EC private key, cosign's own key
+// format, from path.
+func loadCosignKey(path string) (*ecdsa.PrivateKey, error) {
+	raw, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	block, _ := pem.Decode(raw)
+	if block == nil {
+		return nil, errors.New("no PEM block found")
+	}
+
+	key, err := x509.ParseECPrivateKey(block.Bytes)
+	if err != nil {
+		return nil, errors.Wrap(err, "parsing EC private key")
+	}
+	return key, nil
+}
+
+// parseECPublicKey decodes a PEM-encoded PKIX EC public key, the format
+// `cosign public-key` writes alongside a private key.
+func parseECPublicKey(raw []byte) (*ecdsa.PublicKey, error) {
+	block, _ := pem.Decode(raw)
+	if block == nil {
+		return nil, errors.New("no PEM block found")
+	}
+
+	pub, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, errors.Wrap(err, "parsing EC public key")
+	}
+
+	ecKey, ok := pub.(*ecdsa.PublicKey)
+	if !ok {
+		return nil, errors.Errorf("key is %T, not an EC public key", pub)
+	}
+	return ecKey, nil
+}
+
+// signECDSA signs the sha256 digest of msg and returns the ASN.1 signature.
+func signECDSA(key *ecdsa.PrivateKey, msg []byte) ([]byte, error) {
+	digest := sha256.Sum256(msg)
+	return ecdsa.SignASN1(rand.Reader, key, digest[:])
+}
+
+// repoFromTag strips the tag (or digest) suffix off a fully qualified
+// image reference, leaving the bare repository.
+func repoFromTag(imageRef string) (string, error) {
+	ref, err := name.ParseReference(imageRef, name.WeakValidation)
+	if err != nil {
+		return "", errors.Wrapf(err, "parsing image reference %s", imageRef)
+	}
+	return ref.Context().Name(), nil
+}
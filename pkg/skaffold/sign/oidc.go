@@ -0,0 +1,91 @@
+/*
+Copyright 2018 The Skaffold Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sign
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"os"
+
+	"github.com/pkg/errors"
+
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/build"
+)
+
+// DefaultIDTokenSource reads the caller's OIDC identity token from the
+// SKAFFOLD_OIDC_TOKEN environment variable, the same way CI providers
+// (GitHub Actions, GitLab, ...) typically hand a short-lived token to a
+// build step.
+func DefaultIDTokenSource() (string, error) {
+	token := os.Getenv("SKAFFOLD_OIDC_TOKEN")
+	if token == "" {
+		return "", errors.New("SKAFFOLD_OIDC_TOKEN is not set")
+	}
+	return token, nil
+}
+
+// fulcioClient requests a short-lived signing certificate for an ephemeral
+// public key from a Fulcio-like CA, given the caller's OIDC identity token.
+type fulcioClient interface {
+	RequestCertificate(caURL string, pub *ecdsa.PublicKey, idToken string) ([]byte, error)
+}
+
+// OIDCSigner signs artifacts with a fresh key pair generated for every
+// Sign call, certified by a Fulcio-like CA rather than a long-lived key.
+type OIDCSigner struct {
+	caURL   string
+	fulcio  fulcioClient
+	idToken func() (string, error)
+}
+
+// NewOIDCSigner returns an OIDCSigner that certifies ephemeral keys against
+// caURL, authenticating with the token idToken returns.
+func NewOIDCSigner(caURL string, fulcio fulcioClient, idToken func() (string, error)) *OIDCSigner {
+	return &OIDCSigner{caURL: caURL, fulcio: fulcio, idToken: idToken}
+}
+
+// Sign generates a fresh key pair, gets it certified, signs b's payload
+// and pushes both the signature and certificate to the signature tag.
+func (s *OIDCSigner) Sign(b build.Build) error {
+	priv, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return errors.Wrap(err, "generating ephemeral key")
+	}
+
+	token, err := s.idToken()
+	if err != nil {
+		return errors.Wrap(err, "getting OIDC identity token")
+	}
+
+	cert, err := s.fulcio.RequestCertificate(s.caURL, &priv.PublicKey, token)
+	if err != nil {
+		return errors.Wrap(err, "requesting signing certificate")
+	}
+
+	msg, err := newPayload(b)
+	if err != nil {
+		return err
+	}
+
+	sig, err := signECDSA(priv, msg)
+	if err != nil {
+		return errors.Wrap(err, "signing payload")
+	}
+
+	return pushSignature(b, msg, sig, cert)
+}
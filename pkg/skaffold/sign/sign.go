@@ -0,0 +1,85 @@
+/*
+Copyright 2018 The Skaffold Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package sign signs the images a Builder pushes, and verifies those
+// signatures before a Deployer applies manifests that reference them, in
+// a cosign-compatible layout: a signature is pushed as an OCI artifact to
+// `<repo>:sha256-<hex>.sig`, wrapping an in-toto style simple-signing
+// payload.
+package sign
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/build"
+)
+
+// signatureType identifies the payload format, mirroring cosign's simple
+// signing envelope.
+const signatureType = "skaffold signature"
+
+// payload is the document that gets signed for each artifact.
+type payload struct {
+	Critical critical `json:"critical"`
+}
+
+type critical struct {
+	Identity identity `json:"identity"`
+	Image    image    `json:"image"`
+	Type     string   `json:"type"`
+}
+
+type identity struct {
+	DockerReference string `json:"docker-reference"`
+}
+
+type image struct {
+	DockerManifestDigest string `json:"docker-manifest-digest"`
+}
+
+// Signer signs a single built artifact and pushes the signature alongside
+// it. It runs once per artifact, between build and deploy.
+type Signer interface {
+	Sign(b build.Build) error
+}
+
+// newPayload builds the simple-signing payload for a single artifact.
+func newPayload(b build.Build) ([]byte, error) {
+	if b.Digest == "" {
+		return nil, errors.Errorf("artifact %s has no digest to sign", b.ImageName)
+	}
+	p := payload{
+		Critical: critical{
+			Identity: identity{DockerReference: b.Tag},
+			Image:    image{DockerManifestDigest: b.Digest},
+			Type:     signatureType,
+		},
+	}
+	return json.Marshal(p)
+}
+
+// SignatureTag returns the OCI artifact tag cosign-compatible verifiers
+// expect to find a given digest's signature under.
+func SignatureTag(digest string) (string, error) {
+	parts := strings.SplitN(digest, ":", 2)
+	if len(parts) != 2 || parts[1] == "" {
+		return "", errors.Errorf("malformed digest %q", digest)
+	}
+	return "sha256-" + parts[1] + ".sig", nil
+}
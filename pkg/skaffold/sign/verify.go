@@ -0,0 +1,130 @@
+/*
+Copyright 2018 The Skaffold Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sign
+
+import (
+	"crypto/ecdsa"
+	"crypto/sha256"
+	"io/ioutil"
+
+	"github.com/google/go-containerregistry/pkg/authn"
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/pkg/errors"
+
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/build"
+)
+
+// Verify checks that b's signature tag exists and carries a
+// `dev.cosignproject.cosign/signature` annotation. It does not
+// cryptographically verify the signature, so it can't distinguish a
+// legitimate signature from one fabricated by anyone with push access to
+// the repo; it only confirms one was pushed. It's the only check
+// available for a keyless OIDC signature, since there's no CA root
+// configured to validate the certificate chain against. Callers that can
+// supply the signer's public key should use VerifyWithKey instead.
+func Verify(b build.Build) error {
+	_, sig, err := fetchSignature(b)
+	if err != nil {
+		return err
+	}
+	if len(sig) == 0 {
+		return errors.Errorf("image %s has a signature artifact but no signature annotation", b.Tag)
+	}
+	return nil
+}
+
+// VerifyWithKey checks that b's signature tag exists and was produced by
+// the private half of pub, the same check `cosign verify --key` performs
+// for a static-key signature. Use this instead of Verify whenever a
+// trusted public key is available; it's the only one of the two that
+// actually rejects a fabricated signature.
+func VerifyWithKey(b build.Build, pub *ecdsa.PublicKey) error {
+	msg, sig, err := fetchSignature(b)
+	if err != nil {
+		return err
+	}
+
+	digest := sha256.Sum256(msg)
+	if !ecdsa.VerifyASN1(pub, digest[:], sig) {
+		return errors.Errorf("image %s signature does not verify against the configured key", b.Tag)
+	}
+	return nil
+}
+
+// LoadPublicKey reads a PEM-encoded EC public key, cosign's own key
+// format, from path, for use with VerifyWithKey.
+func LoadPublicKey(path string) (*ecdsa.PublicKey, error) {
+	raw, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return parseECPublicKey(raw)
+}
+
+// fetchSignature pulls b's signature artifact and returns the signed
+// payload and the raw signature bytes carried in its annotation.
+func fetchSignature(b build.Build) ([]byte, []byte, error) {
+	tag, err := SignatureTag(b.Digest)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	repo, err := repoFromTag(b.Tag)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	ref, err := name.ParseReference(repo+":"+tag, name.WeakValidation)
+	if err != nil {
+		return nil, nil, errors.Wrapf(err, "parsing signature ref %s:%s", repo, tag)
+	}
+
+	desc, err := remote.Get(ref, remote.WithAuthFromKeychain(authn.DefaultKeychain))
+	if err != nil {
+		return nil, nil, errors.Wrapf(err, "image %s has no signature", b.Tag)
+	}
+
+	img, err := desc.Image()
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "reading signature image")
+	}
+
+	manifest, err := img.Manifest()
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "reading signature manifest")
+	}
+
+	layers, err := img.Layers()
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "reading signature payload layer")
+	}
+	if len(layers) != 1 {
+		return nil, nil, errors.Errorf("expected 1 signature layer, got %d", len(layers))
+	}
+	rc, err := layers[0].Uncompressed()
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "reading signature payload")
+	}
+	defer rc.Close()
+	msg, err := ioutil.ReadAll(rc)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "reading signature payload")
+	}
+
+	return msg, []byte(manifest.Annotations["dev.cosignproject.cosign/signature"]), nil
+}
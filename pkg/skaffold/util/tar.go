@@ -0,0 +1,95 @@
+/*
+Copyright 2018 The Skaffold Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package util
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// CreateTar writes paths, rooted at root, to w as an uncompressed tarball.
+func CreateTar(w io.Writer, root string, paths []string) error {
+	tw := tar.NewWriter(w)
+	defer tw.Close()
+	return addPaths(tw, root, paths, nil)
+}
+
+// CreateTarGz writes paths, rooted at root, to w as a gzip-compressed tarball.
+func CreateTarGz(w io.Writer, root string, paths []string) error {
+	gw := gzip.NewWriter(w)
+	defer gw.Close()
+	return CreateTar(gw, root, paths)
+}
+
+// CreateTarGzWithTimestamp writes paths, rooted at root, to w as a
+// gzip-compressed tarball whose entries all carry modTime instead of their
+// real mtime, so that a build run twice against unchanged source produces a
+// byte-identical context (and therefore a byte-identical image).
+func CreateTarGzWithTimestamp(w io.Writer, root string, paths []string, modTime time.Time) error {
+	gw := gzip.NewWriter(w)
+	defer gw.Close()
+
+	tw := tar.NewWriter(gw)
+	defer tw.Close()
+	return addPaths(tw, root, paths, &modTime)
+}
+
+func addPaths(tw *tar.Writer, root string, paths []string, forceModTime *time.Time) error {
+	for _, p := range paths {
+		absPath := p
+		if !filepath.IsAbs(p) {
+			absPath = filepath.Join(root, p)
+		}
+
+		info, err := os.Stat(absPath)
+		if err != nil {
+			return errors.Wrapf(err, "stating %s", absPath)
+		}
+
+		header, err := tar.FileInfoHeader(info, "")
+		if err != nil {
+			return errors.Wrapf(err, "creating tar header for %s", absPath)
+		}
+		header.Name = filepath.ToSlash(p)
+		if forceModTime != nil {
+			header.ModTime = *forceModTime
+			header.AccessTime = *forceModTime
+			header.ChangeTime = *forceModTime
+		}
+
+		if err := tw.WriteHeader(header); err != nil {
+			return errors.Wrap(err, "writing tar header")
+		}
+
+		f, err := os.Open(absPath)
+		if err != nil {
+			return errors.Wrapf(err, "opening %s", absPath)
+		}
+		_, err = io.Copy(tw, f)
+		f.Close()
+		if err != nil {
+			return errors.Wrapf(err, "writing %s to tar", absPath)
+		}
+	}
+	return nil
+}
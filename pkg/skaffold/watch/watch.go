@@ -0,0 +1,45 @@
+/*
+Copyright 2018 The Skaffold Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package watch notifies skaffold's dev loop when a watched artifact's
+// sources change.
+package watch
+
+import "github.com/GoogleContainerTools/skaffold/pkg/skaffold/config"
+
+// EventType describes why a watch call returned.
+type EventType string
+
+const (
+	// WatchStopEventType is sent when the dev loop should stop watching.
+	WatchStopEventType EventType = "stop"
+	// WatchChangeEventType is sent when a watched file changed.
+	WatchChangeEventType EventType = "change"
+)
+
+// Event describes why Watch returned.
+type Event struct {
+	EventType EventType
+}
+
+// WatchStopEvent, when returned by a Watcher, tells the dev loop to exit.
+var WatchStopEvent = &Event{EventType: WatchStopEventType}
+
+// Watcher blocks until one of the given artifacts' sources changes, or
+// until cancel is closed.
+type Watcher interface {
+	Watch(artifacts []*config.Artifact, ready chan *Event, cancel chan struct{}) (*Event, error)
+}
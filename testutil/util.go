@@ -0,0 +1,47 @@
+/*
+Copyright 2018 The Skaffold Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package testutil holds small assertion helpers shared by skaffold's tests.
+package testutil
+
+import (
+	"reflect"
+	"testing"
+)
+
+// CheckError fails the test if err's presence doesn't match shouldErr.
+func CheckError(t *testing.T, shouldErr bool, err error) {
+	t.Helper()
+	if shouldErr && err == nil {
+		t.Error("Expected error but returned none")
+	}
+	if !shouldErr && err != nil {
+		t.Errorf("Unexpected error: %s", err)
+	}
+}
+
+// CheckErrorAndTypeEquality fails the test if err's presence doesn't match
+// shouldErr, or if actual isn't the same concrete type as expected.
+func CheckErrorAndTypeEquality(t *testing.T, shouldErr bool, err error, expected, actual interface{}) {
+	t.Helper()
+	CheckError(t, shouldErr, err)
+	if shouldErr {
+		return
+	}
+	if reflect.TypeOf(expected) != reflect.TypeOf(actual) {
+		t.Errorf("Expected type %T but got %T", expected, actual)
+	}
+}